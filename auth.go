@@ -0,0 +1,151 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	firebase "firebase.google.com/go"
+	"firebase.google.com/go/auth"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// authorizeEvaluationWrite checks that rc is allowed to submit an evaluation
+// of teamMember under surveyId: the caller must be the survey's evaluator,
+// and teamMember must be one of the survey's invited team members.
+func authorizeEvaluationWrite(ctx context.Context, rc *RequestContext, surveyId, teamMember string) error {
+	snap, err := firestoreClient().Collection("surveys").Doc(surveyId).Get(ctx)
+	if err != nil {
+		return fmt.Errorf("survey not found")
+	}
+	survey := snap.Data()
+
+	if evaluator, _ := survey["evaluator"].(string); evaluator != rc.Email {
+		return fmt.Errorf("caller was not invited to evaluate this survey")
+	}
+
+	members, _ := survey["teamMembers"].([]interface{})
+	for _, m := range members {
+		member, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := member["name"].(string); name == teamMember {
+			return nil
+		}
+	}
+	return fmt.Errorf("teamMember is not part of this survey")
+}
+
+// authorizeWebhookOwner checks that rc created the webhook identified by
+// webhookId, returning its current document so callers can reuse fields
+// (e.g. preserving CreatedBy across an update) without a second read.
+func authorizeWebhookOwner(ctx context.Context, rc *RequestContext, webhookId string) (*models.Webhook, error) {
+	snap, err := firestoreClient().Collection("webhooks").Doc(webhookId).Get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	var webhook models.Webhook
+	if err := snap.DataTo(&webhook); err != nil {
+		return nil, fmt.Errorf("reading webhook: %w", err)
+	}
+	if webhook.CreatedBy != rc.UID {
+		return nil, fmt.Errorf("caller does not own this webhook")
+	}
+	webhook.ID = webhookId
+	return &webhook, nil
+}
+
+var authClient *auth.Client
+
+// initAuth sets up the Firebase Auth client used to verify caller ID tokens.
+func initAuth(ctx context.Context, projectId string) {
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: projectId})
+	if err != nil {
+		log.Fatalf("Failed to initialize Firebase app: %v", err)
+	}
+	client, err := app.Auth(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create Firebase Auth client: %v", err)
+	}
+	authClient = client
+}
+
+type contextKey string
+
+const requestContextKey contextKey = "requestContext"
+
+// RequestContext carries the authenticated caller's identity through a
+// request. UID is empty for anonymous callers.
+type RequestContext struct {
+	UID   string
+	Email string
+}
+
+func (rc *RequestContext) authenticated() bool {
+	return rc != nil && rc.UID != ""
+}
+
+func withRequestContext(ctx context.Context, rc *RequestContext) context.Context {
+	return context.WithValue(ctx, requestContextKey, rc)
+}
+
+func requestContextFrom(ctx context.Context) *RequestContext {
+	rc, _ := ctx.Value(requestContextKey).(*RequestContext)
+	return rc
+}
+
+// requireAuth verifies the Firebase ID token on the Authorization header and
+// attaches a RequestContext to the request, rejecting the request with 401
+// if the header is missing or the token is invalid.
+func requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc, err := verifyCaller(r)
+		if err != nil || !rc.authenticated() {
+			http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withRequestContext(r.Context(), rc)))
+	})
+}
+
+// optionalAuth attaches a RequestContext when a valid token is present, but
+// lets the request through anonymously otherwise. Handlers that need an
+// authenticated caller must check RequestContext.authenticated() themselves.
+func optionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc, err := verifyCaller(r)
+		if err != nil {
+			rc = &RequestContext{}
+		}
+		next.ServeHTTP(w, r.WithContext(withRequestContext(r.Context(), rc)))
+	})
+}
+
+// verifyCaller extracts and verifies the Bearer token on the request, if
+// any. It returns a non-authenticated RequestContext (no error) when the
+// header is simply absent.
+func verifyCaller(r *http.Request) (*RequestContext, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return &RequestContext{}, nil
+	}
+	if !strings.HasPrefix(header, "Bearer ") {
+		return nil, fmt.Errorf("malformed Authorization header")
+	}
+	idToken := strings.TrimPrefix(header, "Bearer ")
+
+	token, err := authClient.VerifyIDToken(r.Context(), idToken)
+	if err != nil {
+		return nil, fmt.Errorf("verifying ID token: %w", err)
+	}
+
+	rc := &RequestContext{UID: token.UID}
+	if email, ok := token.Claims["email"].(string); ok {
+		rc.Email = email
+	}
+	return rc, nil
+}
@@ -0,0 +1,236 @@
+package function
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// maxTransactionalBatch is the largest evaluation batch submitted inside a
+// single Firestore transaction. Bigger batches fall back to BulkWriter,
+// which trades all-or-nothing atomicity for throughput.
+const maxTransactionalBatch = 25
+
+// evaluationResult reports the outcome of storing a single evaluation entry
+// so the caller can retry only the rows that failed.
+type evaluationResult struct {
+	TeamMember string `json:"teamMember"`
+	DocID      string `json:"docId,omitempty"`
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Handler to store evaluations in Firestore
+func storeEvaluations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	idempotencyKey := r.Header.Get("Idempotency-Key")
+
+	// Decode request body
+	var evaluations []models.Evaluation
+	if err := json.NewDecoder(r.Body).Decode(&evaluations); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate, authorize, and compute derived fields for every entry up
+	// front so a bad row never leaves some of the batch written and the
+	// rest rejected.
+	for i := range evaluations {
+		e := &evaluations[i]
+		if err := validate.Struct(e); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+		if err := authorizeEvaluationWrite(ctx, rc, e.SurveyID, e.TeamMember); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		e.AverageGrade = averageGrade(e.Evaluations)
+		if key := idempotencyKeyFor(e, idempotencyKey); key != "" {
+			e.ID = evaluationDocID(e.SurveyID, e.TeamMember, key)
+		}
+		e.SubmittedAt = time.Now()
+	}
+
+	var results []evaluationResult
+	var err error
+	if len(evaluations) <= maxTransactionalBatch {
+		results, err = storeEvaluationsTransactional(ctx, evaluations)
+	} else {
+		results, err = storeEvaluationsBulk(ctx, evaluations)
+	}
+	if err != nil {
+		requestLogger(ctx).Error("Error storing evaluations", "err", err)
+		http.Error(w, "Error storing evaluations", http.StatusInternalServerError)
+		return
+	}
+
+	emitEvaluationEvents(ctx, evaluations, results)
+
+	// 207-style response: per-entry success/failure with the created doc IDs.
+	w.WriteHeader(http.StatusMultiStatus)
+	json.NewEncoder(w).Encode(map[string]interface{}{"results": results})
+}
+
+// emitEvaluationEvents fires evaluation.submitted for every newly created
+// entry, then checks each affected survey for completion.
+func emitEvaluationEvents(ctx context.Context, evaluations []models.Evaluation, results []evaluationResult) {
+	completionChecked := map[string]bool{}
+	for i, result := range results {
+		if result.Status != "created" {
+			continue
+		}
+		e := evaluations[i]
+		dispatcher.publish(ctx, Event{Type: EventEvaluationSubmitted, SurveyID: e.SurveyID, Data: e, Timestamp: e.SubmittedAt})
+
+		if !completionChecked[e.SurveyID] {
+			completionChecked[e.SurveyID] = true
+			maybeEmitSurveyCompleted(ctx, e.SurveyID)
+		}
+	}
+}
+
+// averageGrade computes the mean grade across an evaluation's entries.
+func averageGrade(entries []models.EvaluationEntry) float64 {
+	if len(entries) == 0 {
+		return 0
+	}
+	var total float64
+	for _, e := range entries {
+		total += e.Grade
+	}
+	return total / float64(len(entries))
+}
+
+// idempotencyKeyFor prefers a per-entry clientRequestId over the batch-wide
+// Idempotency-Key header.
+func idempotencyKeyFor(e *models.Evaluation, headerKey string) string {
+	if e.ClientRequestID != "" {
+		return e.ClientRequestID
+	}
+	return headerKey
+}
+
+// evaluationDocID derives a stable Firestore document ID from an
+// idempotency key, scoped to the survey and team member it applies to, so
+// retries collapse to the same document instead of duplicating it.
+func evaluationDocID(surveyId, teamMember, idempotencyKey string) string {
+	sum := sha256.Sum256([]byte(surveyId + "|" + teamMember + "|" + idempotencyKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// evaluationRef resolves the Firestore document reference an evaluation
+// should be written to, generating a fresh ID when no idempotency key was
+// supplied.
+func evaluationRef(e *models.Evaluation) *firestore.DocumentRef {
+	if e.ID != "" {
+		return firestoreClient().Collection("evaluations").Doc(e.ID)
+	}
+	return firestoreClient().Collection("evaluations").NewDoc()
+}
+
+// quarterOf reads the quarter field from an evaluation document, falling
+// back to the old "queater" typo for docs written before the rename.
+func quarterOf(data map[string]interface{}) string {
+	if q, ok := data["quarter"].(string); ok && q != "" {
+		return q
+	}
+	q, _ := data["queater"].(string)
+	return q
+}
+
+// storeEvaluationsTransactional writes all evaluations in a single
+// Firestore transaction: either the whole survey's batch lands, or none of
+// it does. A retry with the same idempotency keys collapses to the prior
+// write. Entries that were already written by an earlier attempt are
+// skipped rather than recreated, so a batch mixing replayed and genuinely
+// new entries still persists the new ones.
+func storeEvaluationsTransactional(ctx context.Context, evaluations []models.Evaluation) ([]evaluationResult, error) {
+	ctx, span := tracer.Start(ctx, "firestore.evaluations.RunTransaction")
+	defer span.End()
+
+	results := make([]evaluationResult, len(evaluations))
+	refs := make([]*firestore.DocumentRef, len(evaluations))
+	for i := range evaluations {
+		refs[i] = evaluationRef(&evaluations[i])
+	}
+
+	err := firestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		existing := make([]bool, len(evaluations))
+		for i, ref := range refs {
+			if _, err := tx.Get(ref); err == nil {
+				existing[i] = true
+			} else if status.Code(err) != codes.NotFound {
+				return fmt.Errorf("checking evaluation for %s: %w", evaluations[i].TeamMember, err)
+			}
+		}
+		for i := range evaluations {
+			if existing[i] {
+				results[i] = evaluationResult{TeamMember: evaluations[i].TeamMember, DocID: refs[i].ID, Status: "duplicate"}
+				continue
+			}
+			e := &evaluations[i]
+			if err := tx.Create(refs[i], e); err != nil {
+				return fmt.Errorf("creating evaluation for %s: %w", e.TeamMember, err)
+			}
+			results[i] = evaluationResult{TeamMember: e.TeamMember, DocID: refs[i].ID, Status: "created"}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// storeEvaluationsBulk writes evaluations via BulkWriter for batches larger
+// than maxTransactionalBatch. Each entry succeeds or fails independently.
+func storeEvaluationsBulk(ctx context.Context, evaluations []models.Evaluation) ([]evaluationResult, error) {
+	ctx, span := tracer.Start(ctx, "firestore.evaluations.BulkWriter")
+	defer span.End()
+
+	bw := firestoreClient().BulkWriter(ctx)
+	refs := make([]*firestore.DocumentRef, len(evaluations))
+	jobs := make([]*firestore.BulkWriterJob, len(evaluations))
+
+	for i := range evaluations {
+		e := &evaluations[i]
+		ref := evaluationRef(e)
+		refs[i] = ref
+		job, err := bw.Create(ref, e)
+		if err != nil {
+			return nil, fmt.Errorf("scheduling evaluation for %s: %w", e.TeamMember, err)
+		}
+		jobs[i] = job
+	}
+	bw.End()
+
+	results := make([]evaluationResult, len(evaluations))
+	for i, job := range jobs {
+		if _, err := job.Results(); err != nil {
+			if status.Code(err) == codes.AlreadyExists {
+				results[i] = evaluationResult{TeamMember: evaluations[i].TeamMember, DocID: refs[i].ID, Status: "duplicate"}
+				continue
+			}
+			results[i] = evaluationResult{TeamMember: evaluations[i].TeamMember, Status: "failed", Error: err.Error()}
+			continue
+		}
+		results[i] = evaluationResult{TeamMember: evaluations[i].TeamMember, DocID: refs[i].ID, Status: "created"}
+	}
+	return results, nil
+}
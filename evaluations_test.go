@@ -0,0 +1,54 @@
+package function
+
+import (
+	"testing"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+func TestEvaluationDocIDCollapsesRetries(t *testing.T) {
+	id1 := evaluationDocID("survey1", "alice", "client-key-1")
+	id2 := evaluationDocID("survey1", "alice", "client-key-1")
+
+	if id1 != id2 {
+		t.Fatalf("expected retries with the same idempotency key to collapse to the same doc ID, got %q and %q", id1, id2)
+	}
+}
+
+func TestEvaluationDocIDScopedToSurveyAndTeamMember(t *testing.T) {
+	base := evaluationDocID("survey1", "alice", "client-key-1")
+
+	if other := evaluationDocID("survey2", "alice", "client-key-1"); other == base {
+		t.Fatalf("expected a different survey to produce a different doc ID")
+	}
+	if other := evaluationDocID("survey1", "bob", "client-key-1"); other == base {
+		t.Fatalf("expected a different team member to produce a different doc ID")
+	}
+	if other := evaluationDocID("survey1", "alice", "client-key-2"); other == base {
+		t.Fatalf("expected a different idempotency key to produce a different doc ID")
+	}
+}
+
+func TestIdempotencyKeyForPrefersClientRequestID(t *testing.T) {
+	e := &models.Evaluation{ClientRequestID: "entry-key"}
+
+	if got := idempotencyKeyFor(e, "header-key"); got != "entry-key" {
+		t.Fatalf("expected the per-entry clientRequestId to win, got %q", got)
+	}
+}
+
+func TestIdempotencyKeyForFallsBackToHeader(t *testing.T) {
+	e := &models.Evaluation{}
+
+	if got := idempotencyKeyFor(e, "header-key"); got != "header-key" {
+		t.Fatalf("expected the header key as a fallback, got %q", got)
+	}
+}
+
+func TestIdempotencyKeyForEmptyWhenNeitherSupplied(t *testing.T) {
+	e := &models.Evaluation{}
+
+	if got := idempotencyKeyFor(e, ""); got != "" {
+		t.Fatalf("expected no idempotency key, got %q", got)
+	}
+}
@@ -0,0 +1,326 @@
+package function
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/iterator"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// Event types emitted across a survey's lifecycle.
+const (
+	EventSurveyCreated       = "survey.created"
+	EventEvaluationSubmitted = "evaluation.submitted"
+	EventSurveyCompleted     = "survey.completed"
+)
+
+// Event is a single lifecycle event fanned out to webhooks and Pub/Sub.
+type Event struct {
+	Type      string      `json:"type"`
+	SurveyID  string      `json:"surveyId"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+var dispatcher *EventDispatcher
+
+// initEvents wires up the event dispatcher used by survey/evaluation
+// handlers to fan events out without waiting for webhook delivery.
+func initEvents(ctx context.Context, projectId string) {
+	dispatcher = newEventDispatcher(ctx, projectId)
+}
+
+// EventDispatcher fans lifecycle events out to webhook subscribers (via a
+// small worker pool) and, if configured, a Pub/Sub topic.
+type EventDispatcher struct {
+	jobs        chan webhookJob
+	pubsubTopic *pubsub.Topic
+}
+
+type webhookJob struct {
+	webhook models.Webhook
+	event   Event
+}
+
+func newEventDispatcher(ctx context.Context, projectId string) *EventDispatcher {
+	d := &EventDispatcher{jobs: make(chan webhookJob, 100)}
+
+	workers := envInt("WEBHOOK_WORKERS", 4)
+	for i := 0; i < workers; i++ {
+		go d.runWorker()
+	}
+
+	if topicName := os.Getenv("PUBSUB_TOPIC"); topicName != "" {
+		client, err := pubsub.NewClient(ctx, projectId)
+		if err != nil {
+			structuredLogger.Error("Error creating Pub/Sub client", "err", err)
+		} else {
+			d.pubsubTopic = client.Topic(topicName)
+		}
+	}
+
+	return d
+}
+
+// publish fans event out to every active webhook subscribed to its type,
+// and to Pub/Sub if configured. Webhook delivery happens asynchronously on
+// the worker pool; publish does not wait for a delivery to finish, but it
+// blocks while the 100-job queue is full.
+func (d *EventDispatcher) publish(ctx context.Context, event Event) {
+	if d.pubsubTopic != nil {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			requestLogger(ctx).Error("Error marshaling event for Pub/Sub", "eventType", event.Type, "err", err)
+		} else {
+			d.pubsubTopic.Publish(ctx, &pubsub.Message{Data: payload})
+		}
+	}
+
+	webhooks, err := activeWebhooksFor(ctx, event.Type)
+	if err != nil {
+		requestLogger(ctx).Error("Error loading webhooks for event", "eventType", event.Type, "err", err)
+		return
+	}
+	for _, wh := range webhooks {
+		d.jobs <- webhookJob{webhook: wh, event: event}
+	}
+}
+
+func (d *EventDispatcher) runWorker() {
+	for job := range d.jobs {
+		deliverWebhook(job.webhook, job.event)
+	}
+}
+
+// deliverWebhook POSTs the signed event to the webhook's URL, retrying with
+// exponential backoff up to WEBHOOK_MAX_RETRIES times.
+func deliverWebhook(wh models.Webhook, event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		structuredLogger.Error("Error marshaling event for webhook", "webhookId", wh.ID, "err", err)
+		return
+	}
+	signature := signPayload(payload, wh.Secret)
+
+	timeout := time.Duration(envInt("WEBHOOK_TIMEOUT_SECONDS", 5)) * time.Second
+	maxRetries := envInt("WEBHOOK_MAX_RETRIES", 3)
+	client := webhookClient(timeout)
+
+	backoff := time.Second
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err := attemptDelivery(client, wh, payload, signature)
+		if err == nil {
+			return
+		}
+		structuredLogger.Warn("Webhook delivery attempt failed",
+			"webhookId", wh.ID, "attempt", attempt+1, "maxAttempts", maxRetries+1, "err", err)
+		if attempt < maxRetries {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// webhookClient returns an http.Client hardened against SSRF at the point
+// where it actually matters: the TCP connection. The "publichttpurl"
+// validator checks Webhook.URL at creation/update time, but that's a
+// time-of-check; by delivery time the host could have been re-pointed at a
+// private address (DNS rebinding), or the destination could issue a redirect
+// to one. Resolving and re-validating here, then dialing the validated IP
+// literal instead of the hostname, closes both: a second DNS lookup inside
+// the transport can't be used to swap in a different, private address after
+// validation passes.
+func webhookClient(timeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialPublicOnly
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// Don't silently follow a redirect to a destination that hasn't
+			// been validated; return the 3xx itself and let attemptDelivery
+			// treat it as a failed delivery.
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublicOnly resolves addr, rejects it unless every resolved IP is
+// public, and then dials that IP literal directly so a second, independent
+// DNS lookup inside net.Dial can't resolve to something different than what
+// was just validated.
+func dialPublicOnly(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", host, err)
+		}
+		for _, a := range addrs {
+			ips = append(ips, a.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses found for %s", host)
+	}
+	for _, ip := range ips {
+		if !models.IsPublicIP(ip) {
+			return nil, fmt.Errorf("refusing to dial non-public address %s for %s", ip, host)
+		}
+	}
+
+	dialer := &net.Dialer{}
+	return dialer.DialContext(ctx, network, net.JoinHostPort(ips[0].String(), port))
+}
+
+func attemptDelivery(client *http.Client, wh models.Webhook, payload []byte, signature string) error {
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-CSAT-Signature", signature)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// signPayload computes the HMAC-SHA256 signature sent in X-CSAT-Signature.
+func signPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// activeWebhooksFor returns active webhooks subscribed to eventType.
+func activeWebhooksFor(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	iter := firestoreClient().Collection("webhooks").
+		Where("active", "==", true).
+		Where("events", "array-contains", eventType).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var webhooks []models.Webhook
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var wh models.Webhook
+		if err := doc.DataTo(&wh); err != nil {
+			requestLogger(ctx).Warn("Skipping unreadable webhook", "docId", doc.Ref.ID, "err", err)
+			continue
+		}
+		wh.ID = doc.Ref.ID
+		webhooks = append(webhooks, wh)
+	}
+	return webhooks, nil
+}
+
+// maybeEmitSurveyCompleted emits survey.completed the first time every
+// invited team member has at least one evaluation on file. Two team members
+// can submit at nearly the same instant, so the survey and evaluations are
+// read and the completedEventSent flag is claimed inside a single Firestore
+// transaction: only the caller that wins the conditional update actually
+// publishes the event, so concurrent callers can't both observe the flag
+// unset and both fire it.
+func maybeEmitSurveyCompleted(ctx context.Context, surveyId string) {
+	surveyRef := firestoreClient().Collection("surveys").Doc(surveyId)
+
+	var survey models.Survey
+	var won bool
+	err := firestoreClient().RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		won = false
+		surveySnap, err := tx.Get(surveyRef)
+		if err != nil {
+			return fmt.Errorf("loading survey: %w", err)
+		}
+		if completed, _ := surveySnap.Data()["completedEventSent"].(bool); completed {
+			return nil
+		}
+		if err := surveySnap.DataTo(&survey); err != nil {
+			return fmt.Errorf("decoding survey: %w", err)
+		}
+
+		submitted := map[string]bool{}
+		iter := tx.Documents(firestoreClient().Collection("evaluations").Where("surveyId", "==", surveyId))
+		defer iter.Stop()
+		for {
+			doc, err := iter.Next()
+			if err == iterator.Done {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("checking evaluations: %w", err)
+			}
+			if teamMember, ok := doc.Data()["teamMember"].(string); ok {
+				submitted[teamMember] = true
+			}
+		}
+
+		for _, member := range survey.TeamMembers {
+			if !submitted[member.Name] {
+				return nil
+			}
+		}
+
+		if err := tx.Update(surveyRef, []firestore.Update{{Path: "completedEventSent", Value: true}}); err != nil {
+			return err
+		}
+		won = true
+		return nil
+	})
+	if err != nil {
+		requestLogger(ctx).Error("Error checking survey completion", "surveyId", surveyId, "err", err)
+		return
+	}
+	if !won {
+		return
+	}
+
+	dispatcher.publish(ctx, Event{Type: EventSurveyCompleted, SurveyID: surveyId, Data: survey, Timestamp: time.Now()})
+}
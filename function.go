@@ -3,28 +3,53 @@ package function
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
 	"github.com/gorilla/mux"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+var rateLimiter *RateLimiter
+var validate = models.NewValidator()
+
+var (
+	firestoreClientOnce sync.Once
+	firestoreClientInst *firestore.Client
 )
 
-var firestoreClient *firestore.Client
+// firestoreClient returns the package's Firestore client, building it on
+// first use rather than at import time. That keeps the package (and its
+// pure-logic unit tests, which never touch Firestore) loadable and
+// testable without live GCP credentials or a configured project ID.
+func firestoreClient() *firestore.Client {
+	firestoreClientOnce.Do(func() {
+		// Remember to change the constructor in the future to use a named database
+		client, err := firestore.NewClient(context.Background(), gcpProjectID)
+		if err != nil {
+			log.Fatalf("Failed to create Firestore client: %v", err)
+		}
+		firestoreClientInst = client
+	})
+	return firestoreClientInst
+}
 
 func init() {
 	ctx := context.Background()
 	projectId := os.Getenv("GOOGLE_PROJECT_ID")
-	// Remember to change the constructor in the future to use a named database
-	client, err := firestore.NewClient(ctx, projectId)
-	if err != nil {
-		log.Fatalf("Failed to create Firestore client: %v", err)
-	}
-	firestoreClient = client
+	gcpProjectID = projectId
+	initLogger()
+
+	initAuth(ctx, projectId)
+	initEvents(ctx, projectId)
+	initTracing(ctx, projectId)
+	rateLimiter = newRateLimiterFromEnv()
 	functions.HTTP("RESTHandler", RESTHandler)
 }
 
@@ -33,13 +58,30 @@ func RESTHandler(w http.ResponseWriter, r *http.Request) {
 	router := mux.NewRouter()
 
 	// Define routes
+	router.HandleFunc("/healthz", healthz).Methods("GET")
+	router.HandleFunc("/readyz", readyz).Methods("GET")
 	router.HandleFunc("/evaluations", storeEvaluations).Methods("POST")
 	router.HandleFunc("/surveys", createSurvey).Methods("POST")
 	router.HandleFunc("/surveys/{surveyId}", getSurvey).Methods("GET")
-	// Serve the request
-	// Wrap the router with CORS middleware
-	corsHandler := enableCORS(router)
-	corsHandler.ServeHTTP(w, r)
+	router.HandleFunc("/surveys/{surveyId}/results", getSurveyResults).Methods("GET")
+	router.HandleFunc("/webhooks", createWebhook).Methods("POST")
+	router.HandleFunc("/webhooks", listWebhooks).Methods("GET")
+	router.HandleFunc("/webhooks/{webhookId}", updateWebhook).Methods("PUT")
+	router.HandleFunc("/webhooks/{webhookId}", deleteWebhook).Methods("DELETE")
+
+	// CORS sits ahead of auth/rate-limiting so preflight OPTIONS requests are
+	// answered before they ever reach them; request ID logging wraps everything
+	// so every response, including errors from the other layers, is traceable;
+	// tracing sits innermost so the Firestore spans it wraps nest under a
+	// span for the whole request.
+	handler := chain(router,
+		withRequestID,
+		enableCORS,
+		optionalAuth,
+		rateLimiter.middleware,
+		traceMiddleware,
+	)
+	handler.ServeHTTP(w, r)
 }
 
 func enableCORS(next http.Handler) http.Handler {
@@ -61,35 +103,47 @@ func enableCORS(next http.Handler) http.Handler {
 
 // 📝 **Handler to create a new survey**
 func createSurvey(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
+
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
 
 	// Parse JSON request body
-	var survey map[string]interface{}
+	var survey models.Survey
 	if err := json.NewDecoder(r.Body).Decode(&survey); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
 	}
 
-	// Ensure required fields are present
-	requiredFields := []string{"quarter", "year", "evaluator", "questions", "teamMembers"}
-	for _, field := range requiredFields {
-		if _, exists := survey[field]; !exists {
-			http.Error(w, fmt.Sprintf("Missing field: %s", field), http.StatusBadRequest)
-			return
-		}
+	if err := validate.Struct(survey); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// The caller must be the evaluator they're creating the survey for.
+	if survey.Evaluator != rc.Email {
+		http.Error(w, "evaluator must match the authenticated caller", http.StatusForbidden)
+		return
 	}
 
-	// Add timestamp
-	survey["createdAt"] = time.Now()
+	survey.CreatedAt = time.Now()
 
 	// Store survey in Firestore with auto-generated ID
-	docRef, _, err := firestoreClient.Collection("surveys").Add(ctx, survey)
+	spanCtx, span := tracer.Start(ctx, "firestore.surveys.Add")
+	docRef, _, err := firestoreClient().Collection("surveys").Add(spanCtx, survey)
+	span.End()
 	if err != nil {
-		log.Printf("Error storing survey: %v", err)
+		requestLogger(ctx).Error("Error storing survey", "err", err)
 		http.Error(w, "Error storing survey", http.StatusInternalServerError)
 		return
 	}
 
+	survey.ID = docRef.ID
+	dispatcher.publish(ctx, Event{Type: EventSurveyCreated, SurveyID: survey.ID, Data: survey, Timestamp: survey.CreatedAt})
+
 	// Respond with survey ID
 	response := map[string]string{"surveyId": docRef.ID}
 	json.NewEncoder(w).Encode(response)
@@ -97,108 +151,36 @@ func createSurvey(w http.ResponseWriter, r *http.Request) {
 
 // 🔍 **Handler to retrieve a survey by ID**
 func getSurvey(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx := r.Context()
 	vars := mux.Vars(r)
 	surveyId := vars["surveyId"]
 
 	// Fetch survey document
-	docRef := firestoreClient.Collection("surveys").Doc(surveyId)
-	docSnap, err := docRef.Get(ctx)
+	spanCtx, span := tracer.Start(ctx, "firestore.surveys.Get")
+	docRef := firestoreClient().Collection("surveys").Doc(surveyId)
+	docSnap, err := docRef.Get(spanCtx)
+	span.End()
 	if err != nil {
 		http.Error(w, "Survey not found", http.StatusNotFound)
 		return
 	}
 
-	// Convert Firestore document to JSON
-	surveyData := docSnap.Data()
-	surveyData["surveyId"] = surveyId // Include surveyId in response
-
-	// Respond with JSON
-	json.NewEncoder(w).Encode(surveyData)
-}
-
-// Handler to store evaluations in Firestore
-func storeEvaluations(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-
-	// Decode request body
-	var evaluations []map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&evaluations); err != nil {
-		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+	var survey models.Survey
+	if err := docSnap.DataTo(&survey); err != nil {
+		requestLogger(ctx).Error("Error decoding survey", "surveyId", surveyId, "err", err)
+		http.Error(w, "Error reading survey", http.StatusInternalServerError)
 		return
 	}
+	survey.ID = surveyId
 
-	// Store each evaluation entry
-	// force deploy
-	for _, evaluation := range evaluations {
-		teamMember, ok := evaluation["teamMember"].(string)
-		if !ok || teamMember == "" {
-			http.Error(w, "Missing teamMember field", http.StatusBadRequest)
-			return
-		}
-		surveyId, ok := evaluation["surveyId"].(string)
-		if !ok || surveyId == "" {
-			http.Error(w, "Missing surveyId field", http.StatusBadRequest)
-			return
-		}
-		quarter, ok := evaluation["quarter"].(string)
-		if !ok || quarter == "" {
-			http.Error(w, "Missing quarter field", http.StatusBadRequest)
-			return
-		}
-		year, ok := evaluation["year"].(float64)
-		if !ok || year < float64(time.Now().Year()) {
-			log.Printf("year %v ", year)
-			log.Printf("ok %v", ok)
-			http.Error(w, "Missing year field", http.StatusBadRequest)
-			return
-		}
-
-		evals, ok := evaluation["evaluations"].([]interface{})
-		if !ok {
-			http.Error(w, "Invalid evaluations field", http.StatusBadRequest)
-			return
-		}
-
-		// Calculate average grade
-		var totalGrade float64
-		var count int
-		for _, e := range evals {
-			evalMap, ok := e.(map[string]interface{})
-			if !ok {
-				continue
-			}
-			if grade, exists := evalMap["grade"].(float64); exists {
-				totalGrade += grade
-				count++
-			}
-		}
-		averageGrade := 0.0
-		if count > 0 {
-			averageGrade = totalGrade / float64(count)
-		}
-
-		// Prepare Firestore document
-		doc := map[string]interface{}{
-			"surveyId":     surveyId,
-			"year":         year,
-			"queater":      quarter,
-			"teamMember":   teamMember,
-			"evaluations":  evals, // Store full evaluations exactly as received
-			"averageGrade": averageGrade,
-			"submittedAt":  time.Now(), // Timestamp for easier querying
-		}
-
-		// Store under `evaluations` collection with an auto-generated ID
-		_, _, err := firestoreClient.Collection("evaluations").Add(ctx, doc)
-		if err != nil {
-			log.Printf("Error storing evaluation for %s: %v", teamMember, err)
-			http.Error(w, "Error storing evaluation", http.StatusInternalServerError)
-			return
-		}
-	}
+	// Respond with JSON
+	json.NewEncoder(w).Encode(survey)
+}
 
-	// Send success response
-	response := map[string]string{"message": "Evaluations stored successfully"}
-	json.NewEncoder(w).Encode(response)
+// writeValidationError responds with a structured 400 listing the fields
+// that failed validation.
+func writeValidationError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{"errors": models.FieldErrors(err)})
 }
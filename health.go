@@ -0,0 +1,31 @@
+package function
+
+import (
+	"net/http"
+
+	"google.golang.org/api/iterator"
+)
+
+// healthz is a liveness probe: it never touches Firestore, so it stays up
+// even if the backing project is having trouble, which is what you want
+// liveness to reflect (is the process alive, not is everything healthy).
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyz is a readiness probe: it pings Firestore so Cloud Run can gate
+// traffic until the client can actually serve requests.
+func readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	iter := firestoreClient().Collections(ctx)
+	if _, err := iter.Next(); err != nil && err != iterator.Done {
+		structuredLogger.Error("Readiness check failed", "err", err)
+		http.Error(w, "Firestore unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ready"))
+}
@@ -0,0 +1,88 @@
+package function
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+)
+
+var structuredLogger *slog.Logger
+var gcpProjectID string
+
+// initLogger configures JSON logging in the shape Cloud Logging
+// auto-parses: "severity" for the level and "message" for the text, so
+// entries get proper log-level coloring and filtering in Cloud Run/Functions
+// logs instead of opaque log.Printf lines.
+func initLogger() {
+	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch a.Key {
+			case slog.LevelKey:
+				a.Key = "severity"
+			case slog.MessageKey:
+				a.Key = "message"
+			}
+			return a
+		},
+	})
+	structuredLogger = slog.New(handler)
+}
+
+type traceContextKey struct{}
+
+// requestTrace is the trace/span pair extracted from an inbound request,
+// used to correlate log entries with Cloud Trace.
+type requestTrace struct {
+	TraceID string
+	SpanID  string
+}
+
+// cloudTracePattern matches the legacy X-Cloud-Trace-Context header:
+// TRACE_ID/SPAN_ID;o=OPTIONS
+var cloudTracePattern = regexp.MustCompile(`^([0-9a-f]{32})/(\d+)`)
+
+// traceparentPattern matches the W3C traceparent header:
+// version-traceid-spanid-flags
+var traceparentPattern = regexp.MustCompile(`^[0-9a-f]{2}-([0-9a-f]{32})-([0-9a-f]{16})-[0-9a-f]{2}$`)
+
+// traceFromRequest extracts the caller's trace context, preferring Google's
+// legacy header since that's what Cloud Load Balancing and Cloud Run inject.
+func traceFromRequest(r *http.Request) requestTrace {
+	if header := r.Header.Get("X-Cloud-Trace-Context"); header != "" {
+		if m := cloudTracePattern.FindStringSubmatch(header); m != nil {
+			return requestTrace{TraceID: m[1], SpanID: m[2]}
+		}
+	}
+	if header := r.Header.Get("traceparent"); header != "" {
+		if m := traceparentPattern.FindStringSubmatch(header); m != nil {
+			return requestTrace{TraceID: m[1], SpanID: m[2]}
+		}
+	}
+	return requestTrace{}
+}
+
+func withTraceContext(ctx context.Context, t requestTrace) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, t)
+}
+
+func traceFromContext(ctx context.Context) requestTrace {
+	t, _ := ctx.Value(traceContextKey{}).(requestTrace)
+	return t
+}
+
+// requestLogger returns the structured logger enriched with this request's
+// trace/span IDs, in the field names Cloud Logging correlates with Cloud
+// Trace automatically. Falls back to the bare logger outside a traced
+// request (e.g. background webhook delivery).
+func requestLogger(ctx context.Context) *slog.Logger {
+	t := traceFromContext(ctx)
+	if t.TraceID == "" || gcpProjectID == "" {
+		return structuredLogger
+	}
+	return structuredLogger.With(
+		slog.String("logging.googleapis.com/trace", "projects/"+gcpProjectID+"/traces/"+t.TraceID),
+		slog.String("logging.googleapis.com/spanId", t.SpanID),
+	)
+}
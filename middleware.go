@@ -0,0 +1,45 @@
+package function
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Middleware wraps an http.Handler to add cross-cutting behavior.
+type Middleware func(http.Handler) http.Handler
+
+// chain applies middlewares around h in order, so mws[0] is the outermost
+// layer and runs first.
+func chain(h http.Handler, mws ...Middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// withRequestID assigns a request ID (reusing X-Request-Id if the caller
+// supplied one), echoes it back on the response, attaches the caller's
+// trace/span IDs to the request context, and logs a structured request
+// line carrying the "httpRequest" fields Cloud Logging understands.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get("X-Request-Id")
+		if reqID == "" {
+			reqID = uuid.NewString()
+		}
+		w.Header().Set("X-Request-Id", reqID)
+
+		ctx := withTraceContext(r.Context(), traceFromRequest(r))
+		r = r.WithContext(ctx)
+
+		requestLogger(ctx).Info("request received",
+			"requestId", reqID,
+			"httpRequest", map[string]string{
+				"requestMethod": r.Method,
+				"requestUrl":    r.URL.Path,
+			},
+		)
+		next.ServeHTTP(w, r)
+	})
+}
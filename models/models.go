@@ -0,0 +1,52 @@
+// Package models defines the typed request/response shapes for surveys and
+// evaluations, replacing ad-hoc map[string]interface{} decoding so fields
+// are validated once, in one place, before anything is written to
+// Firestore.
+package models
+
+import "time"
+
+// Question is a single survey question team members are evaluated against.
+type Question struct {
+	ID   string `json:"id" firestore:"id" validate:"required"`
+	Text string `json:"text" firestore:"text" validate:"required"`
+}
+
+// TeamMember is a person invited to be evaluated as part of a survey.
+type TeamMember struct {
+	Name  string `json:"name" firestore:"name" validate:"required"`
+	Email string `json:"email" firestore:"email" validate:"required,email"`
+}
+
+// Survey is a CSAT survey created by an evaluator for a given quarter/year.
+type Survey struct {
+	ID          string       `json:"surveyId,omitempty" firestore:"-"`
+	Quarter     string       `json:"quarter" firestore:"quarter" validate:"required"`
+	Year        int          `json:"year" firestore:"year" validate:"required,surveyyear"`
+	Evaluator   string       `json:"evaluator" firestore:"evaluator" validate:"required,email"`
+	Questions   []Question   `json:"questions" firestore:"questions" validate:"required,min=1,dive"`
+	TeamMembers []TeamMember `json:"teamMembers" firestore:"teamMembers" validate:"required,min=1,dive"`
+	CreatedAt   time.Time    `json:"createdAt,omitempty" firestore:"createdAt"`
+}
+
+// EvaluationEntry is the grade and comment for a single survey question.
+type EvaluationEntry struct {
+	QuestionID string  `json:"questionId" firestore:"questionId" validate:"required"`
+	Grade      float64 `json:"grade" firestore:"grade" validate:"required,min=1,max=5"`
+	Comment    string  `json:"comment,omitempty" firestore:"comment"`
+}
+
+// Evaluation is one evaluator's graded submission for a team member on a
+// survey. ClientRequestID, when set, is the caller-supplied idempotency key
+// for this entry and is never persisted.
+type Evaluation struct {
+	ID              string            `json:"docId,omitempty" firestore:"-"`
+	ClientRequestID string            `json:"clientRequestId,omitempty" firestore:"-"`
+	SurveyID        string            `json:"surveyId" firestore:"surveyId" validate:"required"`
+	TeamMember      string            `json:"teamMember" firestore:"teamMember" validate:"required"`
+	Quarter         string            `json:"quarter" firestore:"quarter" validate:"required"`
+	Year            int               `json:"year" firestore:"year" validate:"required,surveyyear"`
+	Evaluations     []EvaluationEntry `json:"evaluations" firestore:"evaluations" validate:"required,min=1,dive"`
+	AverageGrade    float64           `json:"averageGrade" firestore:"averageGrade"`
+	SubmittedAt     time.Time         `json:"submittedAt,omitempty" firestore:"submittedAt"`
+}
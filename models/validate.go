@@ -0,0 +1,93 @@
+package models
+
+import (
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator configured with this package's custom
+// rules. Handlers should keep a single long-lived instance rather than
+// constructing one per request.
+func NewValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterValidation("surveyyear", validateSurveyYear)
+	v.RegisterValidation("publichttpurl", validatePublicHTTPURL)
+	return v
+}
+
+// validateSurveyYear accepts the current year or one year on either side of
+// it, instead of rejecting anything before the current year outright. A
+// strict "year >= now" check used to reject a Q4 survey the moment the
+// calendar rolled over to January.
+func validateSurveyYear(fl validator.FieldLevel) bool {
+	year := fl.Field().Int()
+	now := int64(time.Now().Year())
+	return year >= now-1 && year <= now+1
+}
+
+// validatePublicHTTPURL rejects anything but a plain http(s) URL whose host
+// resolves to a public, routable address. Without this, a caller could
+// register a webhook pointing at loopback, link-local (e.g. the GCP
+// metadata server), or other private-range addresses and use this service's
+// outbound requests to probe or reach internal infrastructure (SSRF).
+func validatePublicHTTPURL(fl validator.FieldLevel) bool {
+	u, err := url.Parse(fl.Field().String())
+	if err != nil {
+		return false
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+	host := u.Hostname()
+	if host == "" {
+		return false
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return IsPublicIP(ip)
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !IsPublicIP(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsPublicIP reports whether ip is routable on the public internet, as
+// opposed to loopback, link-local, or other private-range addresses.
+// Exported so callers that must re-validate at connection time (e.g. a
+// webhook dialer guarding against DNS rebinding) can reuse the same check.
+func IsPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsPrivate()
+}
+
+// FieldError is one field-level validation failure, shaped for returning to
+// API callers as structured JSON.
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// FieldErrors flattens a validator error into a list of FieldErrors.
+// Errors that aren't validation errors (e.g. malformed JSON) come back as a
+// single unnamed entry carrying the original message.
+func FieldErrors(err error) []FieldError {
+	verrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []FieldError{{Rule: err.Error()}}
+	}
+	out := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		out = append(out, FieldError{Field: fe.Namespace(), Rule: fe.Tag()})
+	}
+	return out
+}
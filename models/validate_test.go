@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// surveyYearCarrier isolates the "surveyyear" tag for testing without
+// depending on the full Survey/Evaluation shape.
+type surveyYearCarrier struct {
+	Year int `validate:"surveyyear"`
+}
+
+func TestValidateSurveyYear(t *testing.T) {
+	now := time.Now().Year()
+	v := NewValidator()
+
+	for _, year := range []int{now - 1, now, now + 1} {
+		if err := v.Struct(surveyYearCarrier{Year: year}); err != nil {
+			t.Errorf("year %d: expected no error, got %v", year, err)
+		}
+	}
+
+	for _, year := range []int{now - 2, now + 2} {
+		if err := v.Struct(surveyYearCarrier{Year: year}); err == nil {
+			t.Errorf("year %d: expected a validation error, got nil", year)
+		}
+	}
+}
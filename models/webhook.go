@@ -0,0 +1,12 @@
+package models
+
+// Webhook is a subscription that receives survey lifecycle events over
+// HTTP, signed with its Secret.
+type Webhook struct {
+	ID        string   `json:"id,omitempty" firestore:"-"`
+	URL       string   `json:"url" firestore:"url" validate:"required,url,publichttpurl"`
+	Secret    string   `json:"secret,omitempty" firestore:"secret" validate:"required"`
+	Events    []string `json:"events" firestore:"events" validate:"required,min=1,dive,oneof=survey.created evaluation.submitted survey.completed"`
+	Active    bool     `json:"active" firestore:"active"`
+	CreatedBy string   `json:"createdBy,omitempty" firestore:"createdBy"`
+}
@@ -0,0 +1,53 @@
+package function
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer trace.Tracer
+
+// initTracing sets up the OpenTelemetry tracer used to span Firestore
+// calls. Exporting spans to Cloud Trace is opt-in via OTEL_EXPORTER_ENABLED
+// so local/dev runs don't need OTLP collector credentials configured.
+func initTracing(ctx context.Context, projectId string) {
+	tracer = otel.Tracer("tdx-csat-api")
+
+	if os.Getenv("OTEL_EXPORTER_ENABLED") != "true" {
+		return
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		structuredLogger.Error("Error creating OTLP trace exporter", "err", err)
+		return
+	}
+
+	res, err := resource.New(ctx, resource.WithAttributes(semconv.ServiceName("tdx-csat-api")))
+	if err != nil {
+		structuredLogger.Error("Error building OTel resource", "err", err)
+		res = resource.Default()
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	tracer = tp.Tracer("tdx-csat-api")
+}
+
+// traceMiddleware wraps the handler with OpenTelemetry's HTTP instrumentation
+// so every request gets a root span that Firestore call spans nest under.
+func traceMiddleware(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "RESTHandler")
+}
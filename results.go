@@ -0,0 +1,361 @@
+package function
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"github.com/gorilla/mux"
+	"github.com/xuri/excelize/v2"
+	"google.golang.org/api/iterator"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// defaultResultsPageSize is used when the caller doesn't supply ?pageSize.
+const defaultResultsPageSize = 50
+
+// questionResult is a single question's average grade across a team
+// member's evaluations.
+type questionResult struct {
+	QuestionID string  `json:"questionId"`
+	Average    float64 `json:"average"`
+	Count      int     `json:"count"`
+}
+
+// teamMemberResult aggregates a team member's evaluations for a survey.
+// Evaluator identity is intentionally never included here; results are
+// grouped by team member only, for anonymized reporting.
+type teamMemberResult struct {
+	TeamMember       string           `json:"teamMember"`
+	ResponseCount    int              `json:"responseCount"`
+	OverallAverage   float64          `json:"overallAverage"`
+	StdDev           float64          `json:"stdDev"`
+	QuestionAverages []questionResult `json:"questionAverages"`
+}
+
+// surveyResultsPage is one page of aggregated results for a survey.
+type surveyResultsPage struct {
+	SurveyID      string             `json:"surveyId"`
+	TeamMembers   []teamMemberResult `json:"teamMembers"`
+	NextPageToken string             `json:"nextPageToken,omitempty"`
+}
+
+// teamMemberAgg accumulates raw evaluation data before it's finalized into
+// a teamMemberResult.
+type teamMemberAgg struct {
+	teamMember     string
+	grades         []float64
+	questionTotals map[string]float64
+	questionCounts map[string]int
+}
+
+func (a *teamMemberAgg) add(e models.Evaluation) {
+	a.grades = append(a.grades, e.AverageGrade)
+	for _, entry := range e.Evaluations {
+		a.questionTotals[entry.QuestionID] += entry.Grade
+		a.questionCounts[entry.QuestionID]++
+	}
+}
+
+func (a *teamMemberAgg) finalize() teamMemberResult {
+	result := teamMemberResult{TeamMember: a.teamMember, ResponseCount: len(a.grades)}
+
+	if len(a.grades) > 0 {
+		var sum float64
+		for _, g := range a.grades {
+			sum += g
+		}
+		mean := sum / float64(len(a.grades))
+
+		var variance float64
+		for _, g := range a.grades {
+			d := g - mean
+			variance += d * d
+		}
+		variance /= float64(len(a.grades))
+
+		result.OverallAverage = mean
+		result.StdDev = math.Sqrt(variance)
+	}
+
+	for questionID, total := range a.questionTotals {
+		result.QuestionAverages = append(result.QuestionAverages, questionResult{
+			QuestionID: questionID,
+			Average:    total / float64(a.questionCounts[questionID]),
+			Count:      a.questionCounts[questionID],
+		})
+	}
+	sort.Slice(result.QuestionAverages, func(i, j int) bool {
+		return result.QuestionAverages[i].QuestionID < result.QuestionAverages[j].QuestionID
+	})
+
+	return result
+}
+
+// 📊 **Handler to compute aggregated results for a survey**
+func getSurveyResults(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	surveyId := vars["surveyId"]
+	q := r.URL.Query()
+
+	spanCtx, span := tracer.Start(ctx, "firestore.surveys.Get")
+	surveySnap, err := firestoreClient().Collection("surveys").Doc(surveyId).Get(spanCtx)
+	span.End()
+	if err != nil {
+		http.Error(w, "Survey not found", http.StatusNotFound)
+		return
+	}
+	var survey models.Survey
+	if err := surveySnap.DataTo(&survey); err != nil {
+		requestLogger(ctx).Error("Error decoding survey", "surveyId", surveyId, "err", err)
+		http.Error(w, "Error reading survey", http.StatusInternalServerError)
+		return
+	}
+
+	// Only the survey's evaluator can see the aggregated grades it collected.
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() || rc.Email != survey.Evaluator {
+		http.Error(w, "caller is not this survey's evaluator", http.StatusForbidden)
+		return
+	}
+
+	// Evaluation docs don't carry the evaluator's identity, so the evaluator
+	// filter is validated against the survey itself rather than queried.
+	if evaluator := q.Get("evaluator"); evaluator != "" && evaluator != survey.Evaluator {
+		http.Error(w, "evaluator does not match this survey", http.StatusBadRequest)
+		return
+	}
+
+	query := firestoreClient().Collection("evaluations").Where("surveyId", "==", surveyId)
+	if quarter := q.Get("quarter"); quarter != "" {
+		query = query.Where("quarter", "==", quarter)
+	}
+	if yearParam := q.Get("year"); yearParam != "" {
+		year, err := strconv.Atoi(yearParam)
+		if err != nil {
+			http.Error(w, "Invalid year filter", http.StatusBadRequest)
+			return
+		}
+		query = query.Where("year", "==", year)
+	}
+
+	pageSize := defaultResultsPageSize
+	if ps := q.Get("pageSize"); ps != "" {
+		if n, err := strconv.Atoi(ps); err == nil && n > 0 {
+			pageSize = n
+		}
+	}
+
+	teamMembers, nextPageToken, err := aggregateResultsPage(ctx, query, q.Get("pageToken"), pageSize)
+	if err != nil {
+		requestLogger(ctx).Error("Error computing results", "surveyId", surveyId, "err", err)
+		http.Error(w, "Error computing results", http.StatusInternalServerError)
+		return
+	}
+	page := surveyResultsPage{SurveyID: surveyId, TeamMembers: teamMembers, NextPageToken: nextPageToken}
+
+	switch q.Get("format") {
+	case "csv":
+		writeResultsCSV(w, page)
+	case "xlsx":
+		writeResultsXLSX(w, page)
+	default:
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// aggregateResultsPage runs query ordered by teamMember, advances past
+// pageToken via a Firestore cursor, and groups evaluations by team member
+// until it has a full page. Unlike pure raw-document pagination (which can
+// split one team member's evaluations across pages and understate their
+// average, stddev, and response count), this advances by whole team
+// members: it keeps reading only until it has collected pageSize of them in
+// full, then stops at the start of the next one. So the cost of computing a
+// page is bounded by the size of the page (plus the one extra document that
+// reveals there's more), not by the size of the whole survey — the actual
+// cursor-based scaling this endpoint was meant to have.
+//
+// This requires a composite index on (surveyId, teamMember) — and
+// additionally (quarter, teamMember) / (year, teamMember) / etc. for each
+// optional filter combination — which Firestore will prompt to create the
+// first time an unindexed combination is queried.
+func aggregateResultsPage(ctx context.Context, query firestore.Query, pageToken string, pageSize int) ([]teamMemberResult, string, error) {
+	ctx, span := tracer.Start(ctx, "firestore.evaluations.Query")
+	defer span.End()
+
+	query = query.OrderBy("teamMember", firestore.Asc)
+	if pageToken != "" {
+		query = query.StartAfter(pageToken)
+	}
+
+	iter := query.Documents(ctx)
+	defer iter.Stop()
+
+	page := newTeamMemberPage(pageSize)
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", err
+		}
+
+		var e models.Evaluation
+		if err := doc.DataTo(&e); err != nil {
+			requestLogger(ctx).Warn("Skipping unreadable evaluation", "docId", doc.Ref.ID, "err", err)
+			continue
+		}
+		if e.Quarter == "" {
+			// Legacy docs written before the "queater" typo was fixed.
+			e.Quarter = quarterOf(doc.Data())
+		}
+
+		if !page.add(e) {
+			break
+		}
+	}
+
+	return page.finalize(), page.nextPageToken, nil
+}
+
+// teamMemberPage accumulates evaluations, assumed to arrive in teamMember
+// order, into at most pageSize team members. It is kept free of any
+// Firestore dependency so the "stop after pageSize team members" boundary
+// logic can be unit tested without a live query.
+type teamMemberPage struct {
+	pageSize      int
+	order         []string
+	aggs          map[string]*teamMemberAgg
+	current       string
+	nextPageToken string
+}
+
+func newTeamMemberPage(pageSize int) *teamMemberPage {
+	return &teamMemberPage{pageSize: pageSize, aggs: map[string]*teamMemberAgg{}}
+}
+
+// add folds e into the page. It returns false once the page already holds
+// pageSize team members and e belongs to the next one — the caller should
+// stop consuming the iterator at that point. nextPageToken is then the last
+// team member on this page, which the next query starts after.
+func (p *teamMemberPage) add(e models.Evaluation) bool {
+	if e.TeamMember != p.current {
+		if len(p.order) >= p.pageSize {
+			p.nextPageToken = p.current
+			return false
+		}
+		p.current = e.TeamMember
+		p.order = append(p.order, p.current)
+		p.aggs[p.current] = &teamMemberAgg{teamMember: p.current, questionTotals: map[string]float64{}, questionCounts: map[string]int{}}
+	}
+	p.aggs[p.current].add(e)
+	return true
+}
+
+func (p *teamMemberPage) finalize() []teamMemberResult {
+	teamMembers := make([]teamMemberResult, len(p.order))
+	for i, teamMember := range p.order {
+		teamMembers[i] = p.aggs[teamMember].finalize()
+	}
+	return teamMembers
+}
+
+// resultsQuestionIDs returns the sorted, deduplicated set of question IDs
+// present anywhere in page, so the CSV/XLSX exports can lay out one column
+// per question instead of dropping the per-question breakdown the JSON
+// response carries.
+func resultsQuestionIDs(page surveyResultsPage) []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, m := range page.TeamMembers {
+		for _, qa := range m.QuestionAverages {
+			if !seen[qa.QuestionID] {
+				seen[qa.QuestionID] = true
+				ids = append(ids, qa.QuestionID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func writeResultsCSV(w http.ResponseWriter, page surveyResultsPage) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-results.csv", page.SurveyID))
+
+	questionIDs := resultsQuestionIDs(page)
+
+	cw := csv.NewWriter(w)
+	defer cw.Flush()
+
+	header := []string{"teamMember", "responseCount", "overallAverage", "stdDev"}
+	for _, qid := range questionIDs {
+		header = append(header, "q:"+qid)
+	}
+	cw.Write(header)
+
+	for _, m := range page.TeamMembers {
+		row := []string{
+			m.TeamMember,
+			strconv.Itoa(m.ResponseCount),
+			strconv.FormatFloat(m.OverallAverage, 'f', 2, 64),
+			strconv.FormatFloat(m.StdDev, 'f', 2, 64),
+		}
+		row = append(row, questionAverageColumns(m, questionIDs)...)
+		cw.Write(row)
+	}
+}
+
+func writeResultsXLSX(w http.ResponseWriter, page surveyResultsPage) {
+	f := excelize.NewFile()
+	sheet := "Results"
+	f.SetSheetName(f.GetSheetName(0), sheet)
+
+	questionIDs := resultsQuestionIDs(page)
+
+	header := []string{"Team Member", "Responses", "Overall Average", "Std Dev"}
+	for _, qid := range questionIDs {
+		header = append(header, "Q: "+qid)
+	}
+	f.SetSheetRow(sheet, "A1", &header)
+
+	for i, m := range page.TeamMembers {
+		row := fmt.Sprintf("A%d", i+2)
+		cells := []interface{}{m.TeamMember, m.ResponseCount, m.OverallAverage, m.StdDev}
+		for _, col := range questionAverageColumns(m, questionIDs) {
+			cells = append(cells, col)
+		}
+		f.SetSheetRow(sheet, row, &cells)
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-results.xlsx", page.SurveyID))
+	if _, err := f.WriteTo(w); err != nil {
+		structuredLogger.Error("Error writing xlsx results", "err", err)
+	}
+}
+
+// questionAverageColumns renders m's per-question averages in questionIDs
+// order, leaving a blank cell for questions m has no answers for.
+func questionAverageColumns(m teamMemberResult, questionIDs []string) []string {
+	byQuestion := make(map[string]questionResult, len(m.QuestionAverages))
+	for _, qa := range m.QuestionAverages {
+		byQuestion[qa.QuestionID] = qa
+	}
+	cols := make([]string, len(questionIDs))
+	for i, qid := range questionIDs {
+		if qa, ok := byQuestion[qid]; ok {
+			cols[i] = strconv.FormatFloat(qa.Average, 'f', 2, 64)
+		}
+	}
+	return cols
+}
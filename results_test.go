@@ -0,0 +1,104 @@
+package function
+
+import (
+	"math"
+	"testing"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// evalFor builds a minimal evaluation for teamMemberPage tests; only
+// TeamMember matters for the page-boundary logic under test.
+func evalFor(teamMember string) models.Evaluation {
+	return models.Evaluation{TeamMember: teamMember, AverageGrade: 1}
+}
+
+func TestTeamMemberPageSignalsBoundaryAndToken(t *testing.T) {
+	page := newTeamMemberPage(2)
+
+	members := []string{"alice", "bob", "carol"}
+	var stoppedAt string
+	for _, tm := range members {
+		if !page.add(evalFor(tm)) {
+			stoppedAt = tm
+			break
+		}
+	}
+
+	if stoppedAt != "carol" {
+		t.Fatalf("expected the page to stop at the 3rd distinct team member %q, got %q", "carol", stoppedAt)
+	}
+	if page.nextPageToken != "bob" {
+		t.Fatalf("expected next page token %q (the last team member on this page), got %q", "bob", page.nextPageToken)
+	}
+
+	result := page.finalize()
+	if len(result) != 2 || result[0].TeamMember != "alice" || result[1].TeamMember != "bob" {
+		t.Fatalf("unexpected page contents: %+v", result)
+	}
+}
+
+func TestTeamMemberPageNoNextTokenWhenSurveyFits(t *testing.T) {
+	page := newTeamMemberPage(5)
+
+	for _, tm := range []string{"alice", "bob"} {
+		page.add(evalFor(tm))
+	}
+
+	if page.nextPageToken != "" {
+		t.Fatalf("expected no next page token when everything fit on one page, got %q", page.nextPageToken)
+	}
+	if got := len(page.finalize()); got != 2 {
+		t.Fatalf("expected 2 team members, got %d", got)
+	}
+}
+
+func TestTeamMemberPageGroupsMultipleEvaluationsPerMember(t *testing.T) {
+	page := newTeamMemberPage(1)
+
+	for i := 0; i < 3; i++ {
+		if !page.add(evalFor("alice")) {
+			t.Fatalf("did not expect the page to stop within a single team member's own evaluations")
+		}
+	}
+
+	result := page.finalize()
+	if len(result) != 1 || result[0].ResponseCount != 3 {
+		t.Fatalf("expected alice's 3 evaluations to collapse onto one result, got %+v", result)
+	}
+}
+
+func TestTeamMemberAggFinalizeStdDev(t *testing.T) {
+	agg := &teamMemberAgg{
+		teamMember:     "alice",
+		grades:         []float64{2, 4, 4, 4, 5, 5, 7, 9},
+		questionTotals: map[string]float64{},
+		questionCounts: map[string]int{},
+	}
+
+	result := agg.finalize()
+
+	if result.ResponseCount != 8 {
+		t.Fatalf("expected response count 8, got %d", result.ResponseCount)
+	}
+	if math.Abs(result.OverallAverage-5) > 1e-9 {
+		t.Fatalf("expected overall average 5, got %v", result.OverallAverage)
+	}
+	if math.Abs(result.StdDev-2) > 1e-9 {
+		t.Fatalf("expected stddev 2, got %v", result.StdDev)
+	}
+}
+
+func TestTeamMemberAggFinalizeNoGrades(t *testing.T) {
+	agg := &teamMemberAgg{
+		teamMember:     "alice",
+		questionTotals: map[string]float64{},
+		questionCounts: map[string]int{},
+	}
+
+	result := agg.finalize()
+
+	if result.ResponseCount != 0 || result.OverallAverage != 0 || result.StdDev != 0 {
+		t.Fatalf("expected a zero-value result for no grades, got %+v", result)
+	}
+}
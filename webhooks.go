@@ -0,0 +1,136 @@
+package function
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/api/iterator"
+
+	"github.com/alan-alvarenga-telus/tdx-csat-api/models"
+)
+
+// 🔌 **Handler to register a new webhook subscription**
+func createWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if err := validate.Struct(webhook); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	webhook.CreatedBy = rc.UID
+	docRef, _, err := firestoreClient().Collection("webhooks").Add(ctx, webhook)
+	if err != nil {
+		requestLogger(ctx).Error("Error storing webhook", "err", err)
+		http.Error(w, "Error storing webhook", http.StatusInternalServerError)
+		return
+	}
+
+	webhook.ID = docRef.ID
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// Handler to list the caller's own registered webhooks
+func listWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+
+	iter := firestoreClient().Collection("webhooks").Where("createdBy", "==", rc.UID).Documents(ctx)
+	defer iter.Stop()
+
+	webhooks := []models.Webhook{}
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			requestLogger(ctx).Error("Error listing webhooks", "err", err)
+			http.Error(w, "Error listing webhooks", http.StatusInternalServerError)
+			return
+		}
+		var wh models.Webhook
+		if err := doc.DataTo(&wh); err != nil {
+			requestLogger(ctx).Warn("Skipping unreadable webhook", "docId", doc.Ref.ID, "err", err)
+			continue
+		}
+		wh.ID = doc.Ref.ID
+		wh.Secret = "" // never echo the signing secret back in a list response
+		webhooks = append(webhooks, wh)
+	}
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+// Handler to replace a webhook subscription's URL/secret/events
+func updateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	webhookId := mux.Vars(r)["webhookId"]
+
+	if _, err := authorizeWebhookOwner(ctx, rc, webhookId); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if err := validate.Struct(webhook); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	webhook.CreatedBy = rc.UID
+	if _, err := firestoreClient().Collection("webhooks").Doc(webhookId).Set(ctx, webhook); err != nil {
+		requestLogger(ctx).Error("Error updating webhook", "webhookId", webhookId, "err", err)
+		http.Error(w, "Error updating webhook", http.StatusInternalServerError)
+		return
+	}
+
+	webhook.ID = webhookId
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// Handler to remove a webhook subscription
+func deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rc := requestContextFrom(ctx)
+	if !rc.authenticated() {
+		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		return
+	}
+	webhookId := mux.Vars(r)["webhookId"]
+
+	if _, err := authorizeWebhookOwner(ctx, rc, webhookId); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if _, err := firestoreClient().Collection("webhooks").Doc(webhookId).Delete(ctx); err != nil {
+		requestLogger(ctx).Error("Error deleting webhook", "webhookId", webhookId, "err", err)
+		http.Error(w, "Error deleting webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}